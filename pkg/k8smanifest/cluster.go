@@ -0,0 +1,57 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+import (
+	"github.com/pkg/errors"
+	"k8s.io/client-go/rest"
+
+	kubeutil "github.com/sigstore/k8s-manifest-sigstore/pkg/util/kubeutil"
+)
+
+// ClusterTarget identifies one cluster in a fleet that VerifyResource should
+// run its dry-run based match cases against, for users running fleet-style
+// verification (a single verifier binary attesting policy across many
+// clusters). Set either RESTConfig directly, or KubeconfigPath (optionally
+// with Context to select a non-current context within it); RESTConfig takes
+// precedence when both are set.
+type ClusterTarget struct {
+	// Name identifies this cluster in VerifyResourceResult.ClusterResults.
+	Name string
+	// KubeconfigPath is the kubeconfig file to load this cluster from. Left
+	// empty to use the default loading rules (KUBECONFIG env var, falling
+	// back to ~/.kube/config); when Context is empty too, the in-cluster
+	// config is tried first.
+	KubeconfigPath string
+	// Context selects a non-current context within KubeconfigPath.
+	Context string
+	// RESTConfig, if set, is used as-is and KubeconfigPath/Context are
+	// ignored.
+	RESTConfig *rest.Config
+}
+
+// restConfig resolves this ClusterTarget to a *rest.Config.
+func (c ClusterTarget) restConfig() (*rest.Config, error) {
+	if c.RESTConfig != nil {
+		return c.RESTConfig, nil
+	}
+	cfg, err := kubeutil.LoadRESTConfig(c.KubeconfigPath, c.Context)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load kubeconfig for cluster target")
+	}
+	return cfg, nil
+}
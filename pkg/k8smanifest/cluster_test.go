@@ -0,0 +1,42 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+// TestClusterTarget_RESTConfigTakesPrecedence ensures an explicitly supplied
+// RESTConfig is used as-is, without consulting KubeconfigPath/Context.
+func TestClusterTarget_RESTConfigTakesPrecedence(t *testing.T) {
+	want := &rest.Config{Host: "https://cluster.example.com"}
+	target := ClusterTarget{
+		Name:           "prod",
+		KubeconfigPath: "/does/not/exist",
+		RESTConfig:     want,
+	}
+
+	got, err := target.restConfig()
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if got != want {
+		t.Errorf("expected the supplied RESTConfig to be returned as-is")
+	}
+}
@@ -0,0 +1,42 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+// VerifyResourceOption controls how VerifyResource/VerifyResources match a
+// live object against its signed manifest and verify its signature.
+type VerifyResourceOption struct {
+	ImageRef string
+	KeyPath  string
+
+	SkipObjects  ObjectReferenceList
+	IgnoreFields ObjectFieldBindingList
+	Signers      SignerList
+
+	// CheckDryRunForApply enables CASE3 (dryrunApplyMatch) in
+	// matchResourceWithManifest.
+	CheckDryRunForApply bool
+	// CheckSSADryRun enables CASE5 (dryrunServerSideApplyMatch) in
+	// matchResourceWithManifest.
+	CheckSSADryRun bool
+
+	// Clusters, when set, makes VerifyResource(s) run the dry-run based
+	// match cases against every listed cluster instead of just the caller's
+	// ambient kubeconfig, so drift introduced on only some clusters in a
+	// fleet (e.g. by an admission webhook) is caught. See
+	// matchResourceWithManifestAcrossClusters.
+	Clusters []ClusterTarget
+}
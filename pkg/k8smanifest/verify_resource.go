@@ -20,11 +20,13 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
 
 	k8ssigutil "github.com/sigstore/k8s-manifest-sigstore/pkg/util"
 	kubeutil "github.com/sigstore/k8s-manifest-sigstore/pkg/util/kubeutil"
@@ -33,12 +35,46 @@ import (
 
 const defaultDryRunNamespace = "default"
 
+// ssaVerifyFieldManager is the field manager used for the Server-Side Apply
+// dry-run issued by dryrunServerSideApplyMatch.
+const ssaVerifyFieldManager = "k8s-manifest-sigstore-verify"
+
+// Per-resource verification hints a manifest author can set directly on the
+// object (in the spirit of Argo CD's `argocd.argoproj.io/compare-options`),
+// read and honored by VerifyResource/matchResourceWithManifest alongside the
+// caller-supplied VerifyResourceOption.
+const (
+	// IgnoreFieldsAnnotationKey holds a comma-separated list of dotted field
+	// paths, appended to the effective ignore list for this object.
+	IgnoreFieldsAnnotationKey = "cosign.sigstore.dev/ignoreFields"
+	// MatchModeAnnotationKey restricts which CASE branches
+	// matchResourceWithManifest will try for this object. One of
+	// matchMode{Direct,DryRunCreate,DryRunApply,Patch,SSADryRun,Any}.
+	MatchModeAnnotationKey = "cosign.sigstore.dev/matchMode"
+	// SkipVerifyAnnotationKey, when "true", marks this object out of scope
+	// (VerifyResourceResult.InScope = false), same as vo.SkipObjects.
+	SkipVerifyAnnotationKey = "cosign.sigstore.dev/skipVerify"
+)
+
+// Recognised values for the MatchModeAnnotationKey annotation.
+const (
+	matchModeDirect       = "direct"
+	matchModeDryRunCreate = "dryrun-create"
+	matchModeDryRunApply  = "dryrun-apply"
+	matchModePatch        = "patch"
+	matchModeSSADryRun    = "ssa-dryrun"
+	matchModeAny          = "any"
+)
+
 var CommonResourceMaskKeys = []string{
 	fmt.Sprintf("metadata.annotations.\"%s\"", ImageRefAnnotationKey),
 	fmt.Sprintf("metadata.annotations.\"%s\"", SignatureAnnotationKey),
 	fmt.Sprintf("metadata.annotations.\"%s\"", CertificateAnnotationKey),
 	fmt.Sprintf("metadata.annotations.\"%s\"", MessageAnnotationKey),
 	fmt.Sprintf("metadata.annotations.\"%s\"", BundleAnnotationKey),
+	fmt.Sprintf("metadata.annotations.\"%s\"", IgnoreFieldsAnnotationKey),
+	fmt.Sprintf("metadata.annotations.\"%s\"", MatchModeAnnotationKey),
+	fmt.Sprintf("metadata.annotations.\"%s\"", SkipVerifyAnnotationKey),
 	"metadata.annotations.namespace",
 	"metadata.annotations.kubectl.\"kubernetes.io/last-applied-configuration\"",
 	"metadata.managedFields",
@@ -62,6 +98,23 @@ type VerifyResourceResult struct {
 	Signer   string              `json:"signer"`
 	SigRef   string              `json:"sigRef"`
 	Diff     *mapnode.DiffResult `json:"diff"`
+	// ClusterResults holds the per-cluster outcome of the dry-run based match
+	// cases when VerifyResourceOption.Clusters is set, one entry per
+	// configured ClusterTarget. It is nil when Clusters isn't set, in which
+	// case the dry-run cases ran once, against the caller's ambient
+	// kubeconfig, exactly as before multi-cluster support was added.
+	ClusterResults []ClusterVerifyResult `json:"clusterResults,omitempty"`
+}
+
+// ClusterVerifyResult is the outcome of matching a resource against a single
+// cluster in a fleet-style VerifyResourceOption.Clusters verification. Seeing
+// Matched vary across clusters for the same object is itself the signal: it
+// means something (typically an admission webhook) mutated the object
+// differently depending on the cluster it landed on.
+type ClusterVerifyResult struct {
+	Cluster string              `json:"cluster"`
+	Matched bool                `json:"matched"`
+	Diff    *mapnode.DiffResult `json:"diff"`
 }
 
 func (r *VerifyResourceResult) String() string {
@@ -70,26 +123,38 @@ func (r *VerifyResourceResult) String() string {
 }
 
 func VerifyResource(obj unstructured.Unstructured, vo *VerifyResourceOption) (*VerifyResourceResult, error) {
-
 	objBytes, _ := yaml.Marshal(obj.Object)
 
-	verified := false
-	inScope := true // assume that input resource is in scope in verify-resource
-	signerName := ""
-	sigRef := ""
-	var err error
+	imageRef := resolveImageRef(obj, vo)
 
-	// if imageRef is not specified in args and it is found in object annotations, use the found image ref
-	if vo.ImageRef == "" {
-		annotations := obj.GetAnnotations()
-		annoImageRef, found := annotations[ImageRefAnnotationKey]
-		if found {
-			vo.ImageRef = annoImageRef
-		}
+	manifestInRef, err := NewManifestFetcher(imageRef).Fetch(objBytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "YAML manifest not found for this resource")
 	}
+
+	return verifyResourceWithManifestBundle(obj, vo, imageRef, manifestInRef)
+}
+
+// resolveImageRef returns vo.ImageRef, or (if unset) the ImageRefAnnotationKey
+// annotation on obj.
+func resolveImageRef(obj unstructured.Unstructured, vo *VerifyResourceOption) string {
 	if vo.ImageRef != "" {
-		sigRef = vo.ImageRef
+		return vo.ImageRef
 	}
+	return obj.GetAnnotations()[ImageRefAnnotationKey]
+}
+
+// verifyResourceWithManifestBundle runs the actual verification of obj
+// against an already-fetched signed manifest bundle (manifestInRef), which
+// may contain YAML for more than one resource. VerifyResource fetches this
+// bundle itself for a single object; VerifyResources fetches it once per
+// ImageRef and reuses it across every object backed by that image.
+func verifyResourceWithManifestBundle(obj unstructured.Unstructured, vo *VerifyResourceOption, imageRef string, manifestInRef []byte) (*VerifyResourceResult, error) {
+	objBytes, _ := yaml.Marshal(obj.Object)
+
+	verified := false
+	inScope := true // assume that input resource is in scope in verify-resource
+	sigRef := imageRef
 
 	// check if the resource should be skipped or not
 	if vo != nil && len(vo.SkipObjects) > 0 {
@@ -110,13 +175,24 @@ func VerifyResource(obj unstructured.Unstructured, vo *VerifyResourceOption) (*V
 		ignoreFields = append(ignoreFields, fields...)
 	}
 
-	var manifestInRef []byte
-	manifestInRef, err = NewManifestFetcher(vo.ImageRef).Fetch(objBytes)
-	if err != nil {
-		return nil, errors.Wrap(err, "YAML manifest not found for this resource")
+	found, foundBytes := k8ssigutil.FindSingleYaml(manifestInRef, obj.GetAPIVersion(), obj.GetKind(), obj.GetName(), obj.GetNamespace())
+	if !found {
+		return nil, errors.New("failed to find the corresponding manifest YAML file in image")
+	}
+
+	// per-object verification hints declared via annotations. The signed
+	// manifest is the source of truth for all three: the live object can
+	// only narrow what it declares (e.g. un-skip a resource the manifest
+	// marked skippable, or ignore fewer fields than the manifest allows),
+	// never broaden it, since the live object is exactly what an attacker
+	// able to tamper with the deployed resource could also rewrite.
+	if getObjectBoolAnnotation(obj, foundBytes, SkipVerifyAnnotationKey) {
+		inScope = false
 	}
+	ignoreFields = append(ignoreFields, getObjectIgnoreFieldsAnnotation(obj, foundBytes)...)
+	matchMode := getObjectMatchModeAnnotation(obj, foundBytes)
 
-	mnfMatched, diff, err := matchResourceWithManifest(obj, manifestInRef, ignoreFields, vo.CheckDryRunForApply)
+	mnfMatched, diff, clusterResults, err := matchResourceWithManifestAcrossClusters(obj, foundBytes, ignoreFields, vo, matchMode)
 	if err != nil {
 		return nil, errors.Wrap(err, "error occurred during matching manifest")
 	}
@@ -126,7 +202,7 @@ func VerifyResource(obj unstructured.Unstructured, vo *VerifyResourceOption) (*V
 		keyPath = &(vo.KeyPath)
 	}
 
-	sigVerified, signerName, err := NewSignatureVerifier(objBytes, vo.ImageRef, keyPath).Verify()
+	sigVerified, signerName, err := NewSignatureVerifier(objBytes, imageRef, keyPath).Verify()
 	if err != nil {
 		return nil, errors.Wrap(err, "error occured during signature verification")
 	}
@@ -134,15 +210,72 @@ func VerifyResource(obj unstructured.Unstructured, vo *VerifyResourceOption) (*V
 	verified = mnfMatched && sigVerified && vo.Signers.Match(signerName)
 
 	return &VerifyResourceResult{
-		Verified: verified,
-		InScope:  inScope,
-		Signer:   signerName,
-		SigRef:   sigRef,
-		Diff:     diff,
+		Verified:       verified,
+		InScope:        inScope,
+		Signer:         signerName,
+		SigRef:         sigRef,
+		Diff:           diff,
+		ClusterResults: clusterResults,
 	}, nil
 }
 
-func matchResourceWithManifest(obj unstructured.Unstructured, manifestInImage []byte, ignoreFields []string, checkDryRunForApply bool) (bool, *mapnode.DiffResult, error) {
+// matchResourceWithManifestAcrossClusters runs matchResourceWithManifest once
+// per cluster in vo.Clusters, or once against the caller's ambient kubeconfig
+// if vo.Clusters is empty. It requires every cluster to match: a signer's
+// intent is that the same manifest produce the same server-mutated shape on
+// every cluster in the fleet, so a single cluster's drift (e.g. an admission
+// webhook mutating the object only there) fails verification as a whole. The
+// returned diff is the first mismatching cluster's diff, for backward
+// compatible single-cluster callers; the full per-cluster breakdown is in the
+// returned []ClusterVerifyResult.
+func matchResourceWithManifestAcrossClusters(obj unstructured.Unstructured, foundBytes []byte, ignoreFields []string, vo *VerifyResourceOption, matchMode string) (bool, *mapnode.DiffResult, []ClusterVerifyResult, error) {
+	targets := vo.Clusters
+	if len(targets) == 0 {
+		targets = []ClusterTarget{{}}
+	}
+
+	perCluster := make([]ClusterVerifyResult, 0, len(targets))
+	for _, target := range targets {
+		cfg, err := target.restConfig()
+		if err != nil {
+			return false, nil, nil, errors.Wrapf(err, "failed to resolve REST config for cluster %q", target.Name)
+		}
+
+		matched, diff, err := matchResourceWithManifest(cfg, obj, foundBytes, ignoreFields, vo.CheckDryRunForApply, vo.CheckSSADryRun, matchMode)
+		if err != nil {
+			return false, nil, nil, errors.Wrapf(err, "error occured during matching manifest against cluster %q", target.Name)
+		}
+		perCluster = append(perCluster, ClusterVerifyResult{Cluster: target.Name, Matched: matched, Diff: diff})
+	}
+
+	allMatched, firstMismatchDiff := aggregateClusterMatches(perCluster)
+
+	var clusterResults []ClusterVerifyResult
+	if len(vo.Clusters) > 0 {
+		clusterResults = perCluster
+	}
+
+	return allMatched, firstMismatchDiff, clusterResults, nil
+}
+
+// aggregateClusterMatches folds a set of per-cluster match results into a
+// single matched bool (true only if every cluster matched) and the first
+// mismatching cluster's diff.
+func aggregateClusterMatches(results []ClusterVerifyResult) (bool, *mapnode.DiffResult) {
+	allMatched := true
+	var firstMismatchDiff *mapnode.DiffResult
+	for _, r := range results {
+		if !r.Matched {
+			allMatched = false
+			if firstMismatchDiff == nil {
+				firstMismatchDiff = r.Diff
+			}
+		}
+	}
+	return allMatched, firstMismatchDiff
+}
+
+func matchResourceWithManifest(cfg *rest.Config, obj unstructured.Unstructured, foundBytes []byte, ignoreFields []string, checkDryRunForApply, checkSSADryRun bool, matchMode string) (bool, *mapnode.DiffResult, error) {
 
 	apiVersion := obj.GetAPIVersion()
 	kind := obj.GetKind()
@@ -155,39 +288,50 @@ func matchResourceWithManifest(obj unstructured.Unstructured, manifestInImage []
 	isCRD := kind == "CustomResourceDefinition"
 
 	log.Debug("obj: apiVersion", apiVersion, "kind", kind, "name", name)
-	log.Debug("manifest in image:", string(manifestInImage))
+	log.Debug("manifest found for this object:", string(foundBytes))
 
-	found, foundBytes := k8ssigutil.FindSingleYaml(manifestInImage, apiVersion, kind, name, namespace)
-	if !found {
-		return false, nil, errors.New("failed to find the corresponding manifest YAML file in image")
-	}
+	// restrict which CASE branches below are allowed to run for this object,
+	// per its (or its manifest's) cosign.sigstore.dev/matchMode annotation
+	tryDirect := matchMode == "" || matchMode == matchModeAny || matchMode == matchModeDirect
+	tryDryRunCreate := matchMode == "" || matchMode == matchModeAny || matchMode == matchModeDryRunCreate
+	tryDryRunApply := matchMode == "" || matchMode == matchModeAny || matchMode == matchModeDryRunApply
+	tryPatch := matchMode == "" || matchMode == matchModeAny || matchMode == matchModePatch
+	trySSADryRun := matchMode == "" || matchMode == matchModeAny || matchMode == matchModeSSADryRun
 
 	var err error
 	var matched bool
 	var diff *mapnode.DiffResult
 	objBytes, _ := json.Marshal(obj.Object)
+	// a mode whose branches are all disabled compares nothing, so it must not match
+	if !tryDirect && !tryDryRunCreate && !tryPatch && !(checkDryRunForApply && tryDryRunApply) && !(checkSSADryRun && trySSADryRun) {
+		return false, nil, nil
+	}
 
 	// CASE1: direct match
-	matched, _, err = directMatch(objBytes, foundBytes)
-	if err != nil {
-		return false, nil, errors.Wrap(err, "error occured during diract match")
-	}
-	if matched {
-		return true, nil, nil
+	if tryDirect {
+		matched, diff, err = directMatch(objBytes, foundBytes)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "error occured during diract match")
+		}
+		if matched {
+			return true, nil, nil
+		}
 	}
 
 	// CASE2: dryrun create match
-	matched, diff, err = dryrunCreateMatch(objBytes, foundBytes, clusterScope, isCRD)
-	if err != nil {
-		return false, nil, errors.Wrap(err, "error occured during dryrun create match")
-	}
-	if matched {
-		return true, nil, nil
+	if tryDryRunCreate {
+		matched, diff, err = dryrunCreateMatch(cfg, objBytes, foundBytes, clusterScope, isCRD)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "error occured during dryrun create match")
+		}
+		if matched {
+			return true, nil, nil
+		}
 	}
 
 	// CASE3: dryrun apply match
-	if checkDryRunForApply {
-		matched, diff, err = dryrunApplyMatch(objBytes, foundBytes, clusterScope, isCRD)
+	if checkDryRunForApply && tryDryRunApply {
+		matched, diff, err = dryrunApplyMatch(cfg, objBytes, foundBytes, clusterScope, isCRD)
 		if err != nil {
 			return false, nil, errors.Wrap(err, "error occured during dryrun apply match")
 		}
@@ -196,15 +340,27 @@ func matchResourceWithManifest(obj unstructured.Unstructured, manifestInImage []
 		}
 	}
 
-	// TODO: handle patch case
-	// // CASE4: dryrun patch match
-	// matched, diff, err = dryrunPatchMatch(objBytes, foundBytes)
-	// if err != nil {
-	// 	return false, errors.Wrap(err, "error occured during dryrun patch match")
-	// }
-	// if matched {
-	// 	return true, nil
-	// }
+	// CASE4: dryrun patch match
+	if tryPatch {
+		matched, diff, err = dryrunPatchMatch(objBytes, foundBytes, clusterScope, isCRD)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "error occured during dryrun patch match")
+		}
+		if matched {
+			return true, nil, nil
+		}
+	}
+
+	// CASE5: server-side apply dryrun match
+	if checkSSADryRun && trySSADryRun {
+		matched, diff, err = dryrunServerSideApplyMatch(cfg, objBytes, foundBytes, clusterScope, isCRD)
+		if err != nil {
+			return false, nil, errors.Wrap(err, "error occured during server-side apply dryrun match")
+		}
+		if matched {
+			return true, nil, nil
+		}
+	}
 
 	// filter out ignoreFields
 	if diff != nil && len(ignoreFields) > 0 {
@@ -218,6 +374,93 @@ func matchResourceWithManifest(obj unstructured.Unstructured, manifestInImage []
 	return matched, diff, nil
 }
 
+// getManifestStringAnnotation returns the value of annotation key as declared
+// on the signed manifest node for this object. This is the sole source of
+// truth for what a per-resource verification hint is allowed to be: it was
+// set by whoever signed the manifest, not by whoever can write the live
+// object.
+func getManifestStringAnnotation(manifestBytes []byte, key string) string {
+	mnfNode, err := mapnode.NewFromYamlBytes(manifestBytes)
+	if err != nil {
+		return ""
+	}
+	return mnfNode.GetString(fmt.Sprintf("metadata.annotations.\"%s\"", key))
+}
+
+// getObjectBoolAnnotation resolves the effective boolean value of a
+// permission-widening annotation (currently only SkipVerifyAnnotationKey).
+// The manifest's declared value is the ceiling: when it is "false" (or
+// unset), the live object's annotation is never consulted, so a tampered
+// live-only `skipVerify=true` cannot bypass verification on its own. When the
+// manifest says "true", the live annotation may still narrow it back to
+// "false".
+func getObjectBoolAnnotation(obj unstructured.Unstructured, manifestBytes []byte, key string) bool {
+	manifestValue := getManifestStringAnnotation(manifestBytes, key) == "true"
+	if !manifestValue {
+		return false
+	}
+	if liveValue, found := obj.GetAnnotations()[key]; found {
+		return liveValue == "true"
+	}
+	return true
+}
+
+// getObjectMatchModeAnnotation resolves the effective
+// cosign.sigstore.dev/matchMode value. The manifest's declared value is
+// authoritative; a live annotation is only honored when it's identical to
+// it, since there's no general notion of one match mode being "stricter"
+// than another, and a tamperer able to edit the live object could otherwise
+// simply pick whichever CASE branch is easiest to spoof.
+func getObjectMatchModeAnnotation(obj unstructured.Unstructured, manifestBytes []byte) string {
+	manifestValue := getManifestStringAnnotation(manifestBytes, MatchModeAnnotationKey)
+	if liveValue, found := obj.GetAnnotations()[MatchModeAnnotationKey]; found && liveValue == manifestValue {
+		return liveValue
+	}
+	return manifestValue
+}
+
+// getObjectIgnoreFieldsAnnotation returns the effective set of dotted field
+// paths to ignore for this object. The manifest's declared set is the
+// ceiling: the live annotation may only drop entries from it (verify more
+// strictly than the manifest requires), never add a field the manifest
+// didn't already declare ignorable, so a tampered live-only annotation can't
+// broaden what is ignored.
+func getObjectIgnoreFieldsAnnotation(obj unstructured.Unstructured, manifestBytes []byte) []string {
+	manifestFields := splitIgnoreFields(getManifestStringAnnotation(manifestBytes, IgnoreFieldsAnnotationKey))
+	liveValue, found := obj.GetAnnotations()[IgnoreFieldsAnnotationKey]
+	if !found {
+		return manifestFields
+	}
+
+	allowed := map[string]bool{}
+	for _, f := range manifestFields {
+		allowed[f] = true
+	}
+	effective := []string{}
+	for _, f := range splitIgnoreFields(liveValue) {
+		if allowed[f] {
+			effective = append(effective, f)
+		}
+	}
+	return effective
+}
+
+// splitIgnoreFields parses a comma-separated ignoreFields annotation value
+// into its individual dotted field paths.
+func splitIgnoreFields(v string) []string {
+	if v == "" {
+		return nil
+	}
+	fields := []string{}
+	for _, f := range strings.Split(v, ",") {
+		f = strings.TrimSpace(f)
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
 func directMatch(objBytes, manifestBytes []byte) (bool, *mapnode.DiffResult, error) {
 	objNode, err := mapnode.NewFromBytes(objBytes)
 	if err != nil {
@@ -236,7 +479,7 @@ func directMatch(objBytes, manifestBytes []byte) (bool, *mapnode.DiffResult, err
 	return false, diff, nil
 }
 
-func dryrunCreateMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
+func dryrunCreateMatch(cfg *rest.Config, objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
 	objNode, err := mapnode.NewFromBytes(objBytes)
 	if err != nil {
 		return false, nil, errors.Wrap(err, "failed to initialize object node")
@@ -248,9 +491,9 @@ func dryrunCreateMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool)
 	nsMaskedManifestBytes := mnfNode.Mask([]string{"metadata.namespace"}).ToYaml()
 	var simBytes []byte
 	if clusterScope {
-		simBytes, err = kubeutil.DryRunCreate([]byte(nsMaskedManifestBytes), "")
+		simBytes, err = kubeutil.DryRunCreate(cfg, []byte(nsMaskedManifestBytes), "")
 	} else {
-		simBytes, err = kubeutil.DryRunCreate([]byte(nsMaskedManifestBytes), defaultDryRunNamespace)
+		simBytes, err = kubeutil.DryRunCreate(cfg, []byte(nsMaskedManifestBytes), defaultDryRunNamespace)
 	}
 	if err != nil {
 		return false, nil, errors.Wrap(err, "failed to dryrun with the found YAML in image")
@@ -276,13 +519,13 @@ func dryrunCreateMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool)
 	return false, diff, nil
 }
 
-func dryrunApplyMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
+func dryrunApplyMatch(cfg *rest.Config, objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
 	objNode, err := mapnode.NewFromBytes(objBytes)
 	if err != nil {
 		return false, nil, errors.Wrap(err, "failed to initialize object node")
 	}
 	objNamespace := objNode.GetString("metadata.namespace")
-	_, patchedBytes, err := kubeutil.GetApplyPatchBytes(manifestBytes, objNamespace)
+	_, patchedBytes, err := kubeutil.GetApplyPatchBytes(cfg, manifestBytes, objNamespace, isCRD)
 	if err != nil {
 		return false, nil, errors.Wrap(err, "error during getting applied bytes")
 	}
@@ -290,9 +533,9 @@ func dryrunApplyMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool)
 	nsMaskedPatchedNode := patchedNode.Mask([]string{"metadata.namespace"})
 	var simPatchedObj []byte
 	if clusterScope {
-		simPatchedObj, err = kubeutil.DryRunCreate([]byte(nsMaskedPatchedNode.ToYaml()), "")
+		simPatchedObj, err = kubeutil.DryRunCreate(cfg, []byte(nsMaskedPatchedNode.ToYaml()), "")
 	} else {
-		simPatchedObj, err = kubeutil.DryRunCreate([]byte(nsMaskedPatchedNode.ToYaml()), defaultDryRunNamespace)
+		simPatchedObj, err = kubeutil.DryRunCreate(cfg, []byte(nsMaskedPatchedNode.ToYaml()), defaultDryRunNamespace)
 	}
 	if err != nil {
 		return false, nil, errors.Wrap(err, "error during DryRunCreate for apply")
@@ -316,6 +559,126 @@ func dryrunApplyMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool)
 
 }
 
+// dryrunServerSideApplyMatch matches using Kubernetes Server-Side Apply (SSA)
+// dry-run instead of the client-side dryrunApplyMatch above. Because SSA
+// computes field ownership and merges list-map/list-set fields on the server,
+// it handles arbitrary CRDs correctly even though dryrunApplyMatch (which
+// needs an OpenAPI schema client-side for anything beyond plain JSON merge)
+// cannot.
+func dryrunServerSideApplyMatch(cfg *rest.Config, objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
+	objNode, err := mapnode.NewFromBytes(objBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to initialize object node")
+	}
+	objNamespace := objNode.GetString("metadata.namespace")
+	if clusterScope {
+		objNamespace = ""
+	} else if objNamespace == "" {
+		objNamespace = defaultDryRunNamespace
+	}
+
+	simBytes, err := kubeutil.ServerSideApplyDryRun(cfg, manifestBytes, objNamespace, ssaVerifyFieldManager)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "error during ServerSideApplyDryRun")
+	}
+	simNode, err := mapnode.NewFromYamlBytes(simBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to initialize dry-run-generated object node")
+	}
+
+	mask := append([]string{}, CommonResourceMaskKeys...)
+	mask = append(mask, "metadata.name") // masked as in dryrunCreateMatch; the SSA dry-run itself keeps the manifest's name
+	if isCRD {
+		mask = append(mask, "spec.names.kind")
+		mask = append(mask, "spec.names.listKind")
+		mask = append(mask, "spec.names.singular")
+		mask = append(mask, "spec.names.plural")
+	}
+	maskedObjNode := objNode.Mask(mask)
+	maskedSimNode := simNode.Mask(mask)
+	diff := maskedObjNode.Diff(maskedSimNode)
+	if diff == nil || diff.Size() == 0 {
+		return true, nil, nil
+	}
+	return false, diff, nil
+}
+
+// dryrunPatchMatch handles the case where the live object has been mutated by
+// one or more `kubectl apply` calls after it was first created from the
+// signed manifest (CASE2/CASE3 only simulate a single create/apply from
+// scratch, so they fail once the object has drifted this way). It replays the
+// same three-way strategic merge patch algorithm `kubectl apply` itself uses:
+// original (last-applied-configuration, or an empty doc of the same GVK) ->
+// modified (the signed manifest) -> current (the live object) - and compares
+// the patched result against current, the same way every other CASE in this
+// file diffs a simulated result against the live object.
+//
+// That comparison alone has a blind spot, though: for any field the signer
+// hasn't changed since the recorded last-applied-configuration, the computed
+// patch carries no instruction for it, so it passes straight through from
+// current to patched unexamined - current vs patched can never disagree on
+// such a field no matter what current actually holds. A live object tampered
+// on exactly one of those untouched fields (e.g. directly via `kubectl
+// patch`, which doesn't update last-applied-configuration) would pass this
+// check alone. To close that gap, dryrunPatchMatch also runs the same direct
+// current-vs-manifest comparison directMatch (CASE1) makes, which does see
+// untouched fields since it never goes through the patch. Either comparison
+// finding a mismatch fails the whole check; legitimate drift on fields the
+// manifest doesn't manage is tolerated the same way for both, via the
+// caller's ignoreFields filtering applied afterward regardless of which
+// comparison produced the diff.
+func dryrunPatchMatch(objBytes, manifestBytes []byte, clusterScope, isCRD bool) (bool, *mapnode.DiffResult, error) {
+	objNode, err := mapnode.NewFromBytes(objBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to initialize object node")
+	}
+	mnfNode, err := mapnode.NewFromYamlBytes(manifestBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to initialize manifest node")
+	}
+	modifiedBytes := []byte(mnfNode.ToYaml())
+
+	originalBytes, err := kubeutil.LastAppliedConfigBytes(objBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to determine original (last-applied) configuration")
+	}
+
+	_, patchedBytes, err := kubeutil.ThreeWayMergePatch(originalBytes, modifiedBytes, objBytes, isCRD)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to compute three-way merge patch")
+	}
+
+	patchedNode, err := mapnode.NewFromBytes(patchedBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to initialize patched object node")
+	}
+
+	mask := CommonResourceMaskKeys
+	if isCRD {
+		mask = append(mask, "spec.names.kind")
+		mask = append(mask, "spec.names.listKind")
+		mask = append(mask, "spec.names.singular")
+		mask = append(mask, "spec.names.plural")
+	}
+	maskedObjNode := objNode.Mask(mask)
+	maskedPatchedNode := patchedNode.Mask(mask)
+	patchDiff := maskedObjNode.Diff(maskedPatchedNode)
+
+	_, directDiff, err := directMatch(objBytes, manifestBytes)
+	if err != nil {
+		return false, nil, errors.Wrap(err, "failed to directly diff the current object against the manifest")
+	}
+
+	if (patchDiff == nil || patchDiff.Size() == 0) && (directDiff == nil || directDiff.Size() == 0) {
+		return true, nil, nil
+	}
+	diff := patchDiff
+	if diff == nil || diff.Size() == 0 {
+		diff = directDiff
+	}
+	return false, diff, nil
+}
+
 func loadKnownK8sIgnoreFields() ObjectFieldBindingList {
 	var empty ObjectFieldBindingList
 	var knownK8sIgnoreOption *verifyOption
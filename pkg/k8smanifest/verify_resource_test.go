@@ -0,0 +1,225 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+import (
+	"encoding/json"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	mapnode "github.com/sigstore/k8s-manifest-sigstore/pkg/util/mapnode"
+)
+
+// TestDryrunPatchMatch_DetectsTamperedUntouchedField shows the case CASE4 used
+// to miss: a field the signer hasn't changed since the original apply (so the
+// computed patch carries no instruction for it) has been mutated directly on
+// the live object, e.g. via `kubectl patch`, which doesn't update
+// last-applied-configuration. That tampering must still surface as a
+// mismatch, not be laundered as matched=true.
+func TestDryrunPatchMatch_DetectsTamperedUntouchedField(t *testing.T) {
+	// last-applied-configuration still reflects the original, honest apply.
+	lastApplied := `{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"sample","namespace":"default"},"data":{"policy":"deny-all"}}`
+	objBytes := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"sample","namespace":"default","annotations":{"kubectl.kubernetes.io/last-applied-configuration":` + jsonQuote(lastApplied) + `}},"data":{"policy":"allow-all"}}`)
+	// the signed manifest hasn't changed data.policy since that apply.
+	manifestBytes := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"sample","namespace":"default"},"data":{"policy":"deny-all"}}`)
+
+	matched, diff, err := dryrunPatchMatch(objBytes, manifestBytes, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if matched {
+		t.Fatalf("expected tampering on an untouched field to be detected, got matched=true")
+	}
+	if diff == nil || diff.Size() == 0 {
+		t.Errorf("expected a non-empty diff reporting the tampered data.policy field")
+	}
+}
+
+// TestDryrunPatchMatch_ToleratesSignerChangeAndUnmanagedDrift shows CASE4
+// still does what it's for: a signer who has since changed a field (a
+// repeated `kubectl apply`) is honored, and drift on a field the manifest
+// never manages is reported as an ordinary diff the caller can filter with
+// ignoreFields, exactly like CASE1-3.
+func TestDryrunPatchMatch_ToleratesSignerChangeAndUnmanagedDrift(t *testing.T) {
+	lastApplied := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","image":"nginx:1.0"}]}}}}`
+	objBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default","annotations":{"kubectl.kubernetes.io/last-applied-configuration":` + jsonQuote(lastApplied) + `}},"spec":{"replicas":9,"template":{"spec":{"containers":[{"name":"app","image":"nginx:2.0"}]}}}}`)
+	manifestBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","image":"nginx:2.0"}]}}}}`)
+
+	matched, diff, err := dryrunPatchMatch(objBytes, manifestBytes, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if matched {
+		t.Fatalf("expected spec.replicas drift (unmanaged by the manifest) to surface as a diff, not matched=true")
+	}
+
+	ignoreFields := []string{"spec.replicas"}
+	_, filtered, err := diff.Filter(ignoreFields)
+	if err != nil {
+		t.Fatalf("unexpected error filtering diff: %s", err.Error())
+	}
+	if filtered != nil && filtered.Size() != 0 {
+		t.Errorf("expected no diff left once spec.replicas is ignored and the image change is honored, got %v", filtered)
+	}
+}
+
+// TestDryrunPatchMatch_DetectsTamperedTouchedField shows the case the
+// current-vs-patched comparison alone exists to catch: the signer has bumped
+// a field since the recorded last-applied-configuration, but the live object
+// wasn't updated by a real apply - instead it was tampered directly, to a
+// value that doesn't even match the signer's new one. Diffing the patched
+// result against the manifest instead of current would wrongly report
+// matched=true here, since the patch forces the manifest's new value into
+// patched regardless of what current actually holds.
+func TestDryrunPatchMatch_DetectsTamperedTouchedField(t *testing.T) {
+	lastApplied := `{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"template":{"spec":{"containers":[{"name":"app","image":"nginx:2.0"}]}}}}`
+	objBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default","annotations":{"kubectl.kubernetes.io/last-applied-configuration":` + jsonQuote(lastApplied) + `}},"spec":{"template":{"spec":{"containers":[{"name":"app","image":"evil:1.0"}]}}}}`)
+	manifestBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"template":{"spec":{"containers":[{"name":"app","image":"nginx:3.0"}]}}}}`)
+
+	matched, diff, err := dryrunPatchMatch(objBytes, manifestBytes, false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if matched {
+		t.Fatalf("expected the live object's tampered image to be detected despite a signed change to the same field, got matched=true")
+	}
+	if diff == nil || diff.Size() == 0 {
+		t.Errorf("expected a non-empty diff reporting the tampered image field")
+	}
+}
+
+// jsonQuote renders s as a JSON string literal, for embedding a raw JSON
+// document inside another JSON document's string field (as Kubernetes does
+// with the last-applied-configuration annotation).
+func jsonQuote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// TestGetObjectIgnoreFieldsAnnotation_ManifestIsTheCeiling ensures the
+// cosign.sigstore.dev/ignoreFields annotation is read from the signed
+// manifest, with the live object's annotation only able to narrow that set -
+// never add a field the manifest didn't already declare ignorable.
+func TestGetObjectIgnoreFieldsAnnotation_ManifestIsTheCeiling(t *testing.T) {
+	manifestBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","annotations":{"cosign.sigstore.dev/ignoreFields":"spec.replicas, spec.paused"}}}`)
+
+	// live object narrows the manifest's set: only honor spec.replicas.
+	objNarrowing := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":        "sample",
+			"annotations": map[string]interface{}{"cosign.sigstore.dev/ignoreFields": "spec.replicas"},
+		},
+	}}
+	got := getObjectIgnoreFieldsAnnotation(objNarrowing, manifestBytes)
+	if len(got) != 1 || got[0] != "spec.replicas" {
+		t.Errorf("expected the live object's narrower [spec.replicas] to be honored, got %v", got)
+	}
+
+	// live object tries to widen the manifest's set with a field the
+	// manifest never declared: that field must be dropped, not honored.
+	objWidening := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata": map[string]interface{}{
+			"name":        "sample",
+			"annotations": map[string]interface{}{"cosign.sigstore.dev/ignoreFields": "spec.replicas, spec.template.spec.containers"},
+		},
+	}}
+	got = getObjectIgnoreFieldsAnnotation(objWidening, manifestBytes)
+	for _, f := range got {
+		if f == "spec.template.spec.containers" {
+			t.Fatalf("expected the live-only field spec.template.spec.containers to be rejected, got %v", got)
+		}
+	}
+
+	objWithoutAnnotation := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "sample"},
+	}}
+	got = getObjectIgnoreFieldsAnnotation(objWithoutAnnotation, manifestBytes)
+	want := []string{"spec.replicas", "spec.paused"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("expected the full manifest-declared set %v, got %v", want, got)
+	}
+}
+
+// TestGetObjectBoolAnnotation_LiveCannotWidenSkipVerify shows that a
+// live-only cosign.sigstore.dev/skipVerify=true cannot turn on skipping when
+// the signed manifest didn't declare it, but a live skipVerify=false can
+// still narrow a manifest-declared skip back on.
+func TestGetObjectBoolAnnotation_LiveCannotWidenSkipVerify(t *testing.T) {
+	manifestNotSkipped := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"sample"}}`)
+	tamperedObj := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "sample",
+			"annotations": map[string]interface{}{"cosign.sigstore.dev/skipVerify": "true"},
+		},
+	}}
+	if getObjectBoolAnnotation(tamperedObj, manifestNotSkipped, SkipVerifyAnnotationKey) {
+		t.Fatalf("expected a live-only skipVerify=true to be rejected when the manifest didn't declare it")
+	}
+
+	manifestSkipped := []byte(`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"sample","annotations":{"cosign.sigstore.dev/skipVerify":"true"}}}`)
+	narrowingObj := unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name":        "sample",
+			"annotations": map[string]interface{}{"cosign.sigstore.dev/skipVerify": "false"},
+		},
+	}}
+	if getObjectBoolAnnotation(narrowingObj, manifestSkipped, SkipVerifyAnnotationKey) {
+		t.Errorf("expected the live object's narrower skipVerify=false to be honored")
+	}
+}
+
+// TestIgnoreFieldsAnnotation_SurvivesHPAScale shows that a Deployment whose
+// manifest declares `ignoreFields: spec.replicas` still verifies (no diff
+// left) after an HPA has scaled `spec.replicas` on the live object.
+func TestIgnoreFieldsAnnotation_SurvivesHPAScale(t *testing.T) {
+	manifestBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default","annotations":{"cosign.sigstore.dev/ignoreFields":"spec.replicas"}},"spec":{"replicas":1}}`)
+	objBytes := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default","annotations":{"cosign.sigstore.dev/ignoreFields":"spec.replicas"}},"spec":{"replicas":7}}`)
+
+	obj := unstructured.Unstructured{}
+	if err := json.Unmarshal(objBytes, &obj.Object); err != nil {
+		t.Fatalf("failed to unmarshal object: %s", err.Error())
+	}
+
+	matched, diff, err := directMatch(objBytes, manifestBytes)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if matched {
+		t.Fatalf("expected a diff on spec.replicas before filtering")
+	}
+
+	ignoreFields := getObjectIgnoreFieldsAnnotation(obj, manifestBytes)
+	var filtered *mapnode.DiffResult
+	_, filtered, err = diff.Filter(ignoreFields)
+	if err != nil {
+		t.Fatalf("unexpected error filtering diff: %s", err.Error())
+	}
+	if filtered != nil && filtered.Size() != 0 {
+		t.Errorf("expected no diff left after filtering ignoreFields, got %v", filtered)
+	}
+}
@@ -0,0 +1,248 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sort"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// installOrderKinds mirrors the order `kubectl apply`/Helm install resources
+// in: namespaces and CRDs first, then the identity/config primitives
+// workloads tend to depend on, then RBAC, with everything else (workloads,
+// services, ...) applied last. Kinds not listed here keep their relative
+// input order after all of these.
+//
+// Note this ordering does not affect whether any individual dryrun
+// create/apply call in this batch succeeds: each one runs statelessly
+// against the real target cluster (kubeutil.DryRunCreate doesn't persist
+// anything), so a Namespace or CRD referenced by a later object either
+// already exists there or it doesn't, independent of where it sits in this
+// slice. The sort exists so per-object results come back in the same
+// install-shaped order a human would scan a manifest bundle in.
+// AggregateResult.ByGVK is a map, so its entries carry no order.
+var installOrderKinds = []string{
+	"Namespace",
+	"CustomResourceDefinition",
+	"ServiceAccount",
+	"ConfigMap",
+	"Secret",
+	"Role",
+	"RoleBinding",
+	"ClusterRole",
+	"ClusterRoleBinding",
+}
+
+// AggregateResult summarises a VerifyResources call across every submitted
+// object, plus any resources the signer included in the manifest bundle that
+// were never submitted at all (e.g. an attacker dropping a NetworkPolicy from
+// a partial `kubectl apply`).
+type AggregateResult struct {
+	Total              int                    `json:"total"`
+	Verified           int                    `json:"verified"`
+	Failed             int                    `json:"failed"`
+	ByGVK              map[string]*GVKSummary `json:"byGVK"`
+	UnmatchedManifests []ManifestResourceRef  `json:"unmatchedManifests"`
+}
+
+// GVKSummary is the per apiVersion/kind breakdown within an AggregateResult.
+type GVKSummary struct {
+	Total    int `json:"total"`
+	Verified int `json:"verified"`
+	Failed   int `json:"failed"`
+}
+
+// ManifestResourceRef identifies a single resource inside a signed manifest
+// bundle.
+type ManifestResourceRef struct {
+	ImageRef   string `json:"imageRef"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name"`
+}
+
+// VerifyResources verifies a set of resources at once. Compared to calling
+// VerifyResource in a loop, it (1) sorts the input into install order first,
+// purely so per-object results come back in the same
+// Namespace/CRD/RBAC/workload order a human would read a manifest bundle in
+// (the ByGVK map has no order; see installOrderKinds - it has no bearing on whether any single
+// dryrun call succeeds), (2) fetches each distinct ImageRef's signed
+// manifest bundle only once and reuses it across every object backed by that
+// image, and (3) rolls the per-object results up into an AggregateResult
+// that also reports which resources in the signed bundles were never
+// submitted at all.
+//
+// Known limitation: installOrderKinds does NOT make a bundle's Namespace or
+// CRD visible to CASE2/CASE3's dry-run of a dependent object in the same
+// batch. Each dryrunCreateMatch/dryrunApplyMatch call runs statelessly
+// against the real target cluster (kubeutil.DryRunCreate/GetApplyPatchBytes
+// don't persist anything), so a Deployment in a namespace this same batch
+// also declares will still fail those cases if that namespace doesn't
+// already exist on the cluster independent of this call. Verifying such a
+// bundle today requires the prerequisite Namespace/CRD to already be applied
+// out of band first.
+func VerifyResources(objs []unstructured.Unstructured, vo *VerifyResourceOption) ([]VerifyResourceResult, *AggregateResult, error) {
+	ordered := sortByInstallOrder(objs)
+
+	manifestCache := map[string][]byte{}
+	submitted := map[string]bool{} // imageRef + "\n" + apiVersion/kind/namespace/name
+
+	results := make([]VerifyResourceResult, 0, len(ordered))
+	byGVK := map[string]*GVKSummary{}
+	verifiedCount := 0
+
+	for _, obj := range ordered {
+		objBytes, _ := yaml.Marshal(obj.Object)
+		imageRef := resolveImageRef(obj, vo)
+
+		manifestInRef, cached := manifestCache[imageRef]
+		if !cached {
+			fetched, err := NewManifestFetcher(imageRef).Fetch(objBytes)
+			if err != nil {
+				return nil, nil, errors.Wrap(err, "YAML manifest not found for this resource")
+			}
+			manifestCache[imageRef] = fetched
+			manifestInRef = fetched
+		}
+		submitted[manifestResourceKey(imageRef, obj.GetAPIVersion(), obj.GetKind(), obj.GetNamespace(), obj.GetName())] = true
+
+		result, err := verifyResourceWithManifestBundle(obj, vo, imageRef, manifestInRef)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = append(results, *result)
+
+		gvk := obj.GetAPIVersion() + "/" + obj.GetKind()
+		summary, ok := byGVK[gvk]
+		if !ok {
+			summary = &GVKSummary{}
+			byGVK[gvk] = summary
+		}
+		summary.Total++
+		if result.Verified {
+			verifiedCount++
+			summary.Verified++
+		} else {
+			summary.Failed++
+		}
+	}
+
+	unmatched, err := unmatchedManifestResources(manifestCache, submitted)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to determine unmatched manifest resources")
+	}
+
+	aggregate := &AggregateResult{
+		Total:              len(results),
+		Verified:           verifiedCount,
+		Failed:             len(results) - verifiedCount,
+		ByGVK:              byGVK,
+		UnmatchedManifests: unmatched,
+	}
+
+	return results, aggregate, nil
+}
+
+// sortByInstallOrder returns a copy of objs ordered per installOrderKinds,
+// stable within each kind (and for kinds not listed, relative to each other).
+func sortByInstallOrder(objs []unstructured.Unstructured) []unstructured.Unstructured {
+	rank := func(kind string) int {
+		for i, k := range installOrderKinds {
+			if k == kind {
+				return i
+			}
+		}
+		return len(installOrderKinds)
+	}
+	sorted := make([]unstructured.Unstructured, len(objs))
+	copy(sorted, objs)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return rank(sorted[i].GetKind()) < rank(sorted[j].GetKind())
+	})
+	return sorted
+}
+
+func manifestResourceKey(imageRef, apiVersion, kind, namespace, name string) string {
+	return imageRef + "\n" + apiVersion + "\n" + kind + "\n" + namespace + "\n" + name
+}
+
+// unmatchedManifestResources walks every cached signed manifest bundle and
+// reports the resources inside it that were never part of the submitted set.
+func unmatchedManifestResources(manifestCache map[string][]byte, submitted map[string]bool) ([]ManifestResourceRef, error) {
+	unmatched := []ManifestResourceRef{}
+	for imageRef, manifestBytes := range manifestCache {
+		docs, err := splitConcatenatedYAML(manifestBytes)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to split manifest bundle into individual resources")
+		}
+		for _, doc := range docs {
+			var meta struct {
+				APIVersion string `json:"apiVersion"`
+				Kind       string `json:"kind"`
+				Metadata   struct {
+					Name      string `json:"name"`
+					Namespace string `json:"namespace"`
+				} `json:"metadata"`
+			}
+			if err := yaml.Unmarshal(doc, &meta); err != nil {
+				return nil, errors.Wrap(err, "failed to parse a resource in the manifest bundle")
+			}
+			ref := ManifestResourceRef{
+				ImageRef:   imageRef,
+				APIVersion: meta.APIVersion,
+				Kind:       meta.Kind,
+				Namespace:  meta.Metadata.Namespace,
+				Name:       meta.Metadata.Name,
+			}
+			key := manifestResourceKey(imageRef, ref.APIVersion, ref.Kind, ref.Namespace, ref.Name)
+			if !submitted[key] {
+				unmatched = append(unmatched, ref)
+			}
+		}
+	}
+	return unmatched, nil
+}
+
+// splitConcatenatedYAML splits a `---`-concatenated YAML stream (as produced
+// by `kubectl apply -f` of a directory, or a Helm-rendered bundle) into its
+// individual documents.
+func splitConcatenatedYAML(manifestBytes []byte) ([][]byte, error) {
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(bytes.NewReader(manifestBytes)))
+	docs := [][]byte{}
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(bytes.TrimSpace(doc)) == 0 {
+			continue
+		}
+		docs = append(docs, doc)
+	}
+	return docs, nil
+}
@@ -0,0 +1,127 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package k8smanifest
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func unstructuredOf(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+	u := unstructured.Unstructured{Object: map[string]interface{}{}}
+	u.SetAPIVersion(apiVersion)
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+// TestSortByInstallOrder ensures Namespace/CRD/ServiceAccount/RBAC sort ahead
+// of workloads, so dryrun-create/dryrun-apply against them happens first.
+func TestSortByInstallOrder(t *testing.T) {
+	objs := []unstructured.Unstructured{
+		unstructuredOf("apps/v1", "Deployment", "default", "app"),
+		unstructuredOf("rbac.authorization.k8s.io/v1", "RoleBinding", "default", "rb"),
+		unstructuredOf("v1", "Namespace", "", "default"),
+		unstructuredOf("apiextensions.k8s.io/v1", "CustomResourceDefinition", "", "samples.example.com"),
+		unstructuredOf("v1", "ServiceAccount", "default", "sa"),
+	}
+
+	sorted := sortByInstallOrder(objs)
+
+	kindIndex := map[string]int{}
+	for i, o := range sorted {
+		kindIndex[o.GetKind()] = i
+	}
+
+	if kindIndex["Namespace"] > kindIndex["CustomResourceDefinition"] {
+		t.Errorf("expected Namespace to sort before CustomResourceDefinition")
+	}
+	if kindIndex["CustomResourceDefinition"] > kindIndex["ServiceAccount"] {
+		t.Errorf("expected CustomResourceDefinition to sort before ServiceAccount")
+	}
+	if kindIndex["RoleBinding"] > kindIndex["Deployment"] {
+		t.Errorf("expected RoleBinding to sort before Deployment")
+	}
+	if kindIndex["Deployment"] != len(sorted)-1 {
+		t.Errorf("expected Deployment (not in installOrderKinds) to sort last, got index %d", kindIndex["Deployment"])
+	}
+}
+
+// TestSplitConcatenatedYAML checks that a `---`-joined bundle of manifests
+// splits back into its individual documents.
+func TestSplitConcatenatedYAML(t *testing.T) {
+	bundle := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n---\napiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n")
+
+	docs, err := splitConcatenatedYAML(bundle)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(docs) != 2 {
+		t.Fatalf("expected 2 documents, got %d", len(docs))
+	}
+}
+
+// TestUnmatchedManifestResources shows that a resource present in the signed
+// bundle but missing from the submitted set is reported as unmatched (this is
+// what catches a partial-apply attack dropping e.g. a NetworkPolicy).
+func TestUnmatchedManifestResources(t *testing.T) {
+	bundle := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n  namespace: default\n---\napiVersion: networking.k8s.io/v1\nkind: NetworkPolicy\nmetadata:\n  name: deny-all\n  namespace: default\n")
+
+	manifestCache := map[string][]byte{"example.com/app:v1": bundle}
+	submitted := map[string]bool{
+		manifestResourceKey("example.com/app:v1", "v1", "ConfigMap", "default", "a"): true,
+	}
+
+	unmatched, err := unmatchedManifestResources(manifestCache, submitted)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if len(unmatched) != 1 {
+		t.Fatalf("expected 1 unmatched resource, got %d: %v", len(unmatched), unmatched)
+	}
+	if unmatched[0].Kind != "NetworkPolicy" || unmatched[0].Name != "deny-all" {
+		t.Errorf("expected the unmatched NetworkPolicy deny-all, got %+v", unmatched[0])
+	}
+}
+
+// TestAggregateClusterMatches_RequiresAllClustersToMatch shows that a single
+// drifting cluster (e.g. mutated differently by an admission webhook) fails
+// the whole fleet verification, even though most clusters match.
+func TestAggregateClusterMatches_RequiresAllClustersToMatch(t *testing.T) {
+	allMatch := []ClusterVerifyResult{
+		{Cluster: "us-east", Matched: true},
+		{Cluster: "us-west", Matched: true},
+	}
+	matched, diff := aggregateClusterMatches(allMatch)
+	if !matched || diff != nil {
+		t.Errorf("expected all-matching clusters to aggregate to matched=true, diff=nil, got matched=%v diff=%v", matched, diff)
+	}
+
+	oneDrifted := []ClusterVerifyResult{
+		{Cluster: "us-east", Matched: true},
+		{Cluster: "us-west", Matched: false},
+	}
+	matched, diff = aggregateClusterMatches(oneDrifted)
+	if matched {
+		t.Errorf("expected one drifted cluster to fail aggregate verification")
+	}
+	if diff != nil {
+		t.Errorf("expected nil diff since the drifted cluster's diff was nil in this fixture, got %v", diff)
+	}
+}
@@ -0,0 +1,260 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubeutil
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/jsonmergepatch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// lastAppliedConfigAnnotationKey is the annotation `kubectl apply` leaves on
+// a live object recording the manifest it applied last, used as the
+// "original" document in the three-way merge patch computed by
+// GetApplyPatchBytes.
+const lastAppliedConfigAnnotationKey = "kubectl.kubernetes.io/last-applied-configuration"
+
+// LoadRESTConfig resolves a *rest.Config the same way kubectl does: in-cluster
+// config when running inside a Pod, otherwise the kubeconfig at
+// kubeconfigPath (falling back to the default loading rules - KUBECONFIG env
+// var, then ~/.kube/config - when kubeconfigPath is empty), optionally
+// overridden to kubeContext instead of its current-context.
+func LoadRESTConfig(kubeconfigPath, kubeContext string) (*rest.Config, error) {
+	if kubeconfigPath == "" && kubeContext == "" {
+		if cfg, err := rest.InClusterConfig(); err == nil {
+			return cfg, nil
+		}
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfigPath != "" {
+		loadingRules.ExplicitPath = kubeconfigPath
+	}
+	overrides := &clientcmd.ConfigOverrides{}
+	if kubeContext != "" {
+		overrides.CurrentContext = kubeContext
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
+
+// DryRunCreate simulates creating manifestYAML (optionally overriding its
+// namespace) against the cluster described by cfg using the API server's
+// dry-run mode, and returns the object the server would have created -
+// including defaults and admission mutations - without persisting anything.
+// The object is created under the name `<name>-dryrun`, so the dry-run does
+// not collide with a live object of the same name.
+func DryRunCreate(cfg *rest.Config, manifestYAML []byte, namespace string) ([]byte, error) {
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(manifestYAML, &obj.Object); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest YAML")
+	}
+	if namespace != "" {
+		obj.SetNamespace(namespace)
+	}
+	obj.SetName(obj.GetName() + "-dryrun")
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discovery client")
+	}
+	resourceClient, err := resourceInterfaceFor(dynamicClient, discoveryClient, obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve REST mapping for this kind")
+	}
+
+	created, err := resourceClient.Create(context.Background(), &obj, metav1.CreateOptions{DryRun: []string{metav1.DryRunAll}})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to dry-run create this manifest")
+	}
+	return yaml.Marshal(created.Object)
+}
+
+// GetApplyPatchBytes computes the client-side three-way apply patch for
+// manifestYAML (optionally overriding its namespace) against the current
+// live object fetched from the cluster described by cfg, the same way
+// `kubectl apply` computes it: original (the live object's
+// last-applied-configuration, or an empty doc of the same GVK if absent) ->
+// modified (manifestYAML) -> current (the live object fetched here). It
+// returns both the raw patch bytes and the resulting patched object bytes.
+// The caller is expected to feed the latter into DryRunCreate to get the
+// server-mutated shape of the applied object.
+func GetApplyPatchBytes(cfg *rest.Config, manifestYAML []byte, namespace string, isCRD bool) ([]byte, []byte, error) {
+	var manifestObj unstructured.Unstructured
+	if err := yaml.Unmarshal(manifestYAML, &manifestObj.Object); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal manifest YAML")
+	}
+	if namespace != "" {
+		manifestObj.SetNamespace(namespace)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create discovery client")
+	}
+	resourceClient, err := resourceInterfaceFor(dynamicClient, discoveryClient, manifestObj)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to resolve REST mapping for this kind")
+	}
+
+	current, err := resourceClient.Get(context.Background(), manifestObj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to fetch the current live object for apply-patch computation")
+	}
+
+	currentBytes, err := current.MarshalJSON()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal current object to JSON")
+	}
+	modifiedBytes, err := manifestObj.MarshalJSON()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to marshal manifest to JSON")
+	}
+	originalBytes, err := LastAppliedConfigBytes(currentBytes)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to determine original (last-applied) configuration")
+	}
+
+	return ThreeWayMergePatch(originalBytes, modifiedBytes, currentBytes, isCRD)
+}
+
+// LastAppliedConfigBytes returns the
+// kubectl.kubernetes.io/last-applied-configuration annotation value on
+// objBytes (a live object, as JSON or YAML) if present, otherwise an empty
+// document carrying only its apiVersion/kind/name/namespace, so
+// ThreeWayMergePatch still has a well-formed "original" to diff against.
+func LastAppliedConfigBytes(objBytes []byte) ([]byte, error) {
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(objBytes, &obj.Object); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal object")
+	}
+	if lastApplied, found := obj.GetAnnotations()[lastAppliedConfigAnnotationKey]; found && lastApplied != "" {
+		return []byte(lastApplied), nil
+	}
+
+	empty := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	empty.SetAPIVersion(obj.GetAPIVersion())
+	empty.SetKind(obj.GetKind())
+	empty.SetName(obj.GetName())
+	empty.SetNamespace(obj.GetNamespace())
+	return empty.MarshalJSON()
+}
+
+// ThreeWayMergePatch computes the three-way merge patch between originalBytes
+// and modifiedBytes, and returns both the raw patch and the result of
+// applying it to currentBytes - the same algorithm `kubectl apply` itself
+// uses. For kinds known to the client-go scheme (i.e. built-in types) it uses
+// the real strategic-merge patch metadata from the Go struct; for CRDs, where
+// there's no Go struct to derive a patch schema from, it falls back to a
+// three-way JSON merge patch.
+func ThreeWayMergePatch(originalBytes, modifiedBytes, currentBytes []byte, isCRD bool) ([]byte, []byte, error) {
+	if isCRD {
+		patchBytes, err := jsonmergepatch.CreateThreeWayJSONMergePatch(originalBytes, modifiedBytes, currentBytes)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to create three-way JSON merge patch")
+		}
+		patchedBytes, err := jsonMergePatchApply(currentBytes, patchBytes)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "failed to apply JSON merge patch")
+		}
+		return patchBytes, patchedBytes, nil
+	}
+
+	var current unstructured.Unstructured
+	if err := json.Unmarshal(currentBytes, &current.Object); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to unmarshal current object")
+	}
+	lookupPatchMeta, err := strategicPatchMetaFor(current.GroupVersionKind())
+	if err != nil {
+		// no registered Go type (e.g. a custom resource): use the JSON merge patch path
+		return ThreeWayMergePatch(originalBytes, modifiedBytes, currentBytes, true)
+	}
+	patchBytes, err := strategicpatch.CreateThreeWayMergePatch(originalBytes, modifiedBytes, currentBytes, lookupPatchMeta, true)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to create three-way strategic merge patch")
+	}
+	patchedBytes, err := strategicpatch.StrategicMergePatchUsingLookupPatchMeta(currentBytes, patchBytes, lookupPatchMeta)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to apply three-way strategic merge patch")
+	}
+	return patchBytes, patchedBytes, nil
+}
+
+// jsonMergePatchApply applies a JSON merge patch (RFC 7386) to current.
+func jsonMergePatchApply(currentBytes, patchBytes []byte) ([]byte, error) {
+	currentMap := map[string]interface{}{}
+	if err := json.Unmarshal(currentBytes, &currentMap); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal current object")
+	}
+	patchMap := map[string]interface{}{}
+	if err := json.Unmarshal(patchBytes, &patchMap); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal JSON merge patch")
+	}
+	merged := mergeJSONMaps(currentMap, patchMap)
+	return json.Marshal(merged)
+}
+
+// mergeJSONMaps applies an RFC 7386 JSON merge patch document (patch) on top
+// of base, recursively. A null value in patch removes the corresponding key.
+func mergeJSONMaps(base, patch map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range patch {
+		if v == nil {
+			delete(result, k)
+			continue
+		}
+		patchSubMap, patchIsMap := v.(map[string]interface{})
+		baseSubMap, baseIsMap := result[k].(map[string]interface{})
+		if patchIsMap && baseIsMap {
+			result[k] = mergeJSONMaps(baseSubMap, patchSubMap)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+// strategicPatchMetaFor resolves the strategic-merge-patch metadata for the
+// Go type registered in the client-go scheme for gvk.
+func strategicPatchMetaFor(gvk schema.GroupVersionKind) (strategicpatch.LookupPatchMeta, error) {
+	typedObj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil, errors.Wrap(err, "no registered Go type for this kind; treat as CRD instead")
+	}
+	return strategicpatch.NewPatchMetaFromStruct(typedObj)
+}
@@ -0,0 +1,81 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubeutil
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+// TestThreeWayMergePatch_NativeKind exercises the strategic-merge path for a
+// built-in kind: the cluster has scaled `spec.replicas` (a field the signer
+// never set), and a later `kubectl apply` of the signed manifest changed the
+// container image. Replaying the three-way patch on top of current should
+// reproduce current exactly, since current already has the new image and
+// nothing else in the manifest-owned fields has drifted.
+func TestThreeWayMergePatch_NativeKind(t *testing.T) {
+	original := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","image":"nginx:1.0"}]}}}}`)
+	modified := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"replicas":1,"template":{"spec":{"containers":[{"name":"app","image":"nginx:2.0"}]}}}}`)
+	current := []byte(`{"apiVersion":"apps/v1","kind":"Deployment","metadata":{"name":"sample","namespace":"default"},"spec":{"replicas":5,"template":{"spec":{"containers":[{"name":"app","image":"nginx:2.0"}]}}}}`)
+
+	_, patchedBytes, err := ThreeWayMergePatch(original, modified, current, false)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var patched map[string]interface{}
+	if err := json.Unmarshal(patchedBytes, &patched); err != nil {
+		t.Fatalf("failed to unmarshal patched result: %s", err.Error())
+	}
+
+	spec := patched["spec"].(map[string]interface{})
+	if replicas := spec["replicas"]; replicas != float64(5) {
+		t.Errorf("expected patched replicas to keep the live value 5, got %v", replicas)
+	}
+	containers := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	image := containers[0].(map[string]interface{})["image"]
+	if image != "nginx:2.0" {
+		t.Errorf("expected patched image to be nginx:2.0, got %v", image)
+	}
+}
+
+// TestThreeWayMergePatch_CRDFallback exercises the JSON-merge-patch fallback
+// used for CRDs, which have no Go struct to derive strategic-merge metadata
+// from.
+func TestThreeWayMergePatch_CRDFallback(t *testing.T) {
+	original := []byte(`{"apiVersion":"example.com/v1","kind":"Sample","metadata":{"name":"sample"},"spec":{"size":1,"color":"blue"}}`)
+	modified := []byte(`{"apiVersion":"example.com/v1","kind":"Sample","metadata":{"name":"sample"},"spec":{"size":1,"color":"red"}}`)
+	current := []byte(`{"apiVersion":"example.com/v1","kind":"Sample","metadata":{"name":"sample"},"spec":{"size":3,"color":"red"}}`)
+
+	_, patchedBytes, err := ThreeWayMergePatch(original, modified, current, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+
+	var patched map[string]interface{}
+	if err := json.Unmarshal(patchedBytes, &patched); err != nil {
+		t.Fatalf("failed to unmarshal patched result: %s", err.Error())
+	}
+
+	spec := patched["spec"].(map[string]interface{})
+	if size := spec["size"]; size != float64(3) {
+		t.Errorf("expected patched size to keep the live value 3, got %v", size)
+	}
+	if color := spec["color"]; color != "red" {
+		t.Errorf("expected patched color to be red, got %v", color)
+	}
+}
@@ -0,0 +1,113 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubeutil
+
+import (
+	"context"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+)
+
+const defaultSSAFieldManager = "k8s-manifest-sigstore-verify"
+
+// ServerSideApplyDryRun issues a Server-Side Apply PATCH (content-type
+// application/apply-patch+yaml) of manifestYAML against the cluster
+// described by cfg, with `dryRun=All` and `force=true`, and returns the
+// object the server would have produced. Unlike DryRunCreate/
+// GetApplyPatchBytes, field ownership and list-map/list-set merges are
+// computed by the API server itself, so this also works correctly for CRDs
+// that have no client-side OpenAPI schema to merge against.
+func ServerSideApplyDryRun(cfg *rest.Config, manifestYAML []byte, namespace, fieldManager string) ([]byte, error) {
+	if fieldManager == "" {
+		fieldManager = defaultSSAFieldManager
+	}
+
+	var obj unstructured.Unstructured
+	if err := yaml.Unmarshal(manifestYAML, &obj.Object); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal manifest YAML")
+	}
+	if namespace != "" {
+		obj.SetNamespace(namespace)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create dynamic client")
+	}
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create discovery client")
+	}
+
+	resourceClient, err := resourceInterfaceFor(dynamicClient, discoveryClient, obj)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve REST mapping for this kind")
+	}
+
+	objJSON, err := obj.MarshalJSON()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal manifest to JSON")
+	}
+
+	force := true
+	patched, err := resourceClient.Patch(context.Background(), obj.GetName(), types.ApplyPatchType, objJSON, metav1.PatchOptions{
+		DryRun:       []string{metav1.DryRunAll},
+		FieldManager: fieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to server-side-apply dry-run this manifest")
+	}
+
+	return yaml.Marshal(patched.Object)
+}
+
+// resourceInterfaceFor resolves obj's GroupVersionKind to a namespaced or
+// cluster-scoped dynamic.ResourceInterface using live API discovery.
+func resourceInterfaceFor(dynamicClient dynamic.Interface, discoveryClient discovery.DiscoveryInterface, obj unstructured.Unstructured) (dynamic.ResourceInterface, error) {
+	gvr, namespaced, err := restMappingFor(discoveryClient, obj.GroupVersionKind())
+	if err != nil {
+		return nil, err
+	}
+	if namespaced {
+		return dynamicClient.Resource(gvr).Namespace(obj.GetNamespace()), nil
+	}
+	return dynamicClient.Resource(gvr), nil
+}
+
+func restMappingFor(discoveryClient discovery.DiscoveryInterface, gvk schema.GroupVersionKind) (schema.GroupVersionResource, bool, error) {
+	groupResources, err := restmapper.GetAPIGroupResources(discoveryClient)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	mapper := restmapper.NewDiscoveryRESTMapper(groupResources)
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return schema.GroupVersionResource{}, false, err
+	}
+	return mapping.Resource, mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
@@ -0,0 +1,72 @@
+//
+// Copyright 2020 IBM Corporation
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+//
+
+package kubeutil
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newFakeDiscoveryClient() *fakediscovery.FakeDiscovery {
+	clientset := fake.NewSimpleClientset()
+	fakeDiscovery, _ := clientset.Discovery().(*fakediscovery.FakeDiscovery)
+	fakeDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "apps/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Kind: "Deployment", Namespaced: true},
+			},
+		},
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "namespaces", Kind: "Namespace", Namespaced: false},
+			},
+		},
+	}
+	return fakeDiscovery
+}
+
+func TestRestMappingFor_Namespaced(t *testing.T) {
+	gvr, namespaced, err := restMappingFor(newFakeDiscoveryClient(), schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if !namespaced {
+		t.Errorf("expected Deployment to be namespaced")
+	}
+	if gvr.Resource != "deployments" {
+		t.Errorf("expected resource 'deployments', got %q", gvr.Resource)
+	}
+}
+
+func TestRestMappingFor_ClusterScoped(t *testing.T) {
+	gvr, namespaced, err := restMappingFor(newFakeDiscoveryClient(), schema.GroupVersionKind{Group: "", Version: "v1", Kind: "Namespace"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err.Error())
+	}
+	if namespaced {
+		t.Errorf("expected Namespace to be cluster-scoped")
+	}
+	if gvr.Resource != "namespaces" {
+		t.Errorf("expected resource 'namespaces', got %q", gvr.Resource)
+	}
+}